@@ -0,0 +1,131 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+)
+
+// clusterAPICluster is the subset of a Cluster API (machine.openshift.io/v1beta1)
+// Cluster object's spec this renderer needs: the pod and service network
+// CIDRs used to seed etcd's bootstrap topology.
+type clusterAPICluster struct {
+	Spec struct {
+		ClusterNetwork struct {
+			Pods struct {
+				CIDRBlocks []string `json:"cidrBlocks"`
+			} `json:"pods"`
+			Services struct {
+				CIDRBlocks []string `json:"cidrBlocks"`
+			} `json:"services"`
+		} `json:"clusterNetwork"`
+	} `json:"spec"`
+}
+
+// clusterAPIMachine is the subset of a Cluster API Machine object this
+// renderer needs: the addresses used to derive etcd peer URLs.
+type clusterAPIMachine struct {
+	Status struct {
+		Addresses []struct {
+			Type    string `json:"type"`
+			Address string `json:"address"`
+		} `json:"addresses"`
+	} `json:"status"`
+}
+
+// applyClusterAPIConfig cross-checks the Cluster API config's pod/service
+// CIDRs against the ones already read from the Network CR, preferring the
+// Cluster API values when both are present -- mirroring the installer's
+// shift from InstallConfig to []Machine. Disagreements are logged to warnOut
+// rather than treated as fatal, since both CRs are ultimately derived from
+// the same install-config during a normal install.
+func (t *TemplateData) applyClusterAPIConfig(file string, warnOut io.Writer) error {
+	if file == "" {
+		return nil
+	}
+
+	cluster := &clusterAPICluster{}
+	if err := readYAMLFile(file, cluster); err != nil {
+		return fmt.Errorf("failed to read cluster API config %q: %w", file, err)
+	}
+
+	if pods := cluster.Spec.ClusterNetwork.Pods.CIDRBlocks; len(pods) > 0 {
+		if warnOut != nil && !equalCIDRs(pods, t.ClusterCIDR) {
+			fmt.Fprintf(warnOut, "cluster API pod CIDRs %v disagree with Network CR cluster CIDRs %v; using cluster API values\n", pods, t.ClusterCIDR)
+		}
+		t.ClusterCIDR = pods
+	}
+
+	if services := cluster.Spec.ClusterNetwork.Services.CIDRBlocks; len(services) > 0 {
+		if warnOut != nil && !equalCIDRs(services, t.ServiceCIDR) {
+			fmt.Fprintf(warnOut, "cluster API service CIDRs %v disagree with Network CR service CIDRs %v; using cluster API values\n", services, t.ServiceCIDR)
+		}
+		t.ServiceCIDR = services
+		t.setStackAddressing()
+	}
+
+	return nil
+}
+
+// equalCIDRs reports whether a and b contain the same CIDRs, ignoring order.
+func equalCIDRs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// peerAddressesFromMachines reads Machine objects from files and returns
+// their internal IP addresses in the same order, for use as etcd peer URLs
+// and the initial cluster string.
+func peerAddressesFromMachines(files []string) ([]string, error) {
+	addresses := make([]string, 0, len(files))
+	for _, file := range files {
+		machine := &clusterAPIMachine{}
+		if err := readYAMLFile(file, machine); err != nil {
+			return nil, fmt.Errorf("failed to read machine config %q: %w", file, err)
+		}
+
+		addr, err := internalIPFromMachine(machine)
+		if err != nil {
+			return nil, fmt.Errorf("machine config %q: %w", file, err)
+		}
+		addresses = append(addresses, addr)
+	}
+	return addresses, nil
+}
+
+// internalIPFromMachine returns the first InternalIP address in the
+// Machine's status, matching the address type node bootstrapping uses to
+// join etcd peers.
+func internalIPFromMachine(machine *clusterAPIMachine) (string, error) {
+	for _, addr := range machine.Status.Addresses {
+		if addr.Type == "InternalIP" {
+			return addr.Address, nil
+		}
+	}
+	return "", fmt.Errorf("no InternalIP address found in machine status")
+}
+
+// initialClusterFromPeers renders the etcd --initial-cluster string from
+// peer addresses, e.g. "etcd-member-0=https://10.0.0.1:2380,...". IPv6
+// addresses are bracketed via net.JoinHostPort so the result is a parseable
+// host:port URL.
+func initialClusterFromPeers(peers []string) string {
+	members := make([]string, 0, len(peers))
+	for i, peer := range peers {
+		members = append(members, fmt.Sprintf("etcd-member-%d=https://%s", i, net.JoinHostPort(peer, "2380")))
+	}
+	return strings.Join(members, ",")
+}
@@ -0,0 +1,150 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+var clusterAPIConfigIPv6Only = `
+apiVersion: machine.openshift.io/v1beta1
+kind: Cluster
+metadata:
+  creationTimestamp: null
+  name: cluster
+  namespace: openshift-machine-api
+spec:
+  clusterNetwork:
+    pods:
+      cidrBlocks:
+      - fd01::/48
+    serviceDomain: ""
+    services:
+      cidrBlocks:
+        - fd02::/112
+  providerSpec: {}
+status: {}
+`
+
+func writeTempYAML(t *testing.T, contents string) string {
+	t.Helper()
+
+	file, err := ioutil.TempFile("/tmp", "cluster-api-config.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := ioutil.WriteFile(file.Name(), []byte(contents), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Name()
+}
+
+func TestApplyClusterAPIConfigIPv6Only(t *testing.T) {
+	file := writeTempYAML(t, clusterAPIConfigIPv6Only)
+	defer os.Remove(file)
+
+	data := &TemplateData{
+		ClusterCIDR: []string{"10.128.0.0/14"},
+		ServiceCIDR: []string{"172.30.0.0/16"},
+	}
+
+	if err := data.applyClusterAPIConfig(file, nil); err != nil {
+		t.Fatalf("applyClusterAPIConfig() error = %v", err)
+	}
+
+	if got, want := data.ClusterCIDR, []string{"fd01::/48"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("ClusterCIDR = %v, want %v", got, want)
+	}
+	if got, want := data.ServiceCIDR, []string{"fd02::/112"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("ServiceCIDR = %v, want %v", got, want)
+	}
+	if !data.SingleStackIPv6 {
+		t.Error("SingleStackIPv6 = false, want true for an IPv6-only Cluster API service CIDR")
+	}
+	if data.EtcdAddress.LocalHost != "[::1]" {
+		t.Errorf("LocalHost = %q, want %q", data.EtcdAddress.LocalHost, "[::1]")
+	}
+}
+
+func TestApplyClusterAPIConfigDisagreesWithNetworkCR(t *testing.T) {
+	file := writeTempYAML(t, clusterAPIConfig)
+	defer os.Remove(file)
+
+	// The Network CR reports IPv4-only CIDRs; the Cluster API fixture
+	// disagrees on the pod CIDR (IPv6) but agrees on the service CIDR.
+	data := &TemplateData{
+		ClusterCIDR: []string{"10.128.0.0/14"},
+		ServiceCIDR: []string{"172.30.0.0/16"},
+	}
+
+	var warnings bytes.Buffer
+	if err := data.applyClusterAPIConfig(file, &warnings); err != nil {
+		t.Fatalf("applyClusterAPIConfig() error = %v", err)
+	}
+
+	if got, want := data.ClusterCIDR, "2001:db8::/32"; len(got) != 1 || got[0] != want {
+		t.Errorf("ClusterCIDR = %v, want [%s] (Cluster API values should win)", got, want)
+	}
+	if got, want := data.ServiceCIDR, "172.30.0.0/16"; len(got) != 1 || got[0] != want {
+		t.Errorf("ServiceCIDR = %v, want [%s]", got, want)
+	}
+	if warnings.Len() == 0 {
+		t.Error("expected a disagreement warning for the pod CIDR, got none")
+	}
+}
+
+func TestPeerAddressesFromMachines(t *testing.T) {
+	machine0 := writeTempYAML(t, `
+apiVersion: machine.openshift.io/v1beta1
+kind: Machine
+metadata:
+  name: master-0
+status:
+  addresses:
+  - type: InternalIP
+    address: 10.0.0.1
+`)
+	defer os.Remove(machine0)
+
+	machine1 := writeTempYAML(t, `
+apiVersion: machine.openshift.io/v1beta1
+kind: Machine
+metadata:
+  name: master-1
+status:
+  addresses:
+  - type: ExternalIP
+    address: 198.51.100.1
+  - type: InternalIP
+    address: 10.0.0.2
+`)
+	defer os.Remove(machine1)
+
+	peers, err := peerAddressesFromMachines([]string{machine0, machine1})
+	if err != nil {
+		t.Fatalf("peerAddressesFromMachines() error = %v", err)
+	}
+
+	want := []string{"10.0.0.1", "10.0.0.2"}
+	if len(peers) != len(want) || peers[0] != want[0] || peers[1] != want[1] {
+		t.Errorf("peerAddressesFromMachines() = %v, want %v", peers, want)
+	}
+
+	wantInitialCluster := "etcd-member-0=https://10.0.0.1:2380,etcd-member-1=https://10.0.0.2:2380"
+	if got := initialClusterFromPeers(peers); got != wantInitialCluster {
+		t.Errorf("initialClusterFromPeers() = %q, want %q", got, wantInitialCluster)
+	}
+}
+
+func TestInitialClusterFromPeersBracketsIPv6(t *testing.T) {
+	peers := []string{"fd01::1", "fd01::2"}
+
+	want := "etcd-member-0=https://[fd01::1]:2380,etcd-member-1=https://[fd01::2]:2380"
+	if got := initialClusterFromPeers(peers); got != want {
+		t.Errorf("initialClusterFromPeers() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+// Package options holds the flag-bound configuration for the "render"
+// subcommands: where installer assets are read from and written to, and the
+// manifest data substituted into the bootkube templates.
+package options
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// GenericOptions holds the values shared by all render subcommands: where to
+// read installer-provided config from, where to write the rendered
+// manifests to, and where the manifest templates themselves live on disk.
+type GenericOptions struct {
+	AssetInputDir    string
+	AssetOutputDir   string
+	ConfigOutputFile string
+	TemplatesDir     string
+}
+
+// NewGenericOptions returns a GenericOptions populated with the render
+// command's defaults.
+func NewGenericOptions() *GenericOptions {
+	return &GenericOptions{
+		TemplatesDir: "/usr/share/bootkube/manifests",
+	}
+}
+
+// AddFlags binds the generic render flags shared by all render subcommands.
+func (o *GenericOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.AssetInputDir, "asset-input-dir", o.AssetInputDir, "Input path for asset directory.")
+	fs.StringVar(&o.AssetOutputDir, "asset-output-dir", o.AssetOutputDir, "Output path for rendered manifests.")
+	fs.StringVar(&o.ConfigOutputFile, "config-output-file", o.ConfigOutputFile, "Output path for the completed etcd config file.")
+	fs.StringVar(&o.TemplatesDir, "templates-input-dir", o.TemplatesDir, "Input path for the manifest templates.")
+}
+
+// Images holds the pull specs baked into the rendered etcd static pod
+// manifest.
+type Images struct {
+	Etcd string
+}
+
+// AddFlags binds the image override flags.
+func (i *Images) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&i.Etcd, "manifest-etcd-image", i.Etcd, "Image to use for etcd.")
+}
+
+// EtcdAddress holds the addresses substituted into the rendered etcd static
+// pod manifest.
+type EtcdAddress struct {
+	// LocalHost is the loopback address used for etcd's local health checks.
+	// It is "127.0.0.1" for IPv4 and dual-stack clusters and "[::1]" for
+	// IPv6 single-stack clusters.
+	LocalHost string
+}
+
+// ManifestConfig holds the data substituted into the etcd bootkube manifest
+// templates.
+type ManifestConfig struct {
+	Images
+	EtcdAddress
+}
+
+// ManifestOptions wraps a ManifestConfig with the name of the manifest set
+// it configures (e.g. "etcd"), mirroring the other bootkube render commands.
+type ManifestOptions struct {
+	Name string
+	ManifestConfig
+}
+
+// NewManifestOptions returns a ManifestOptions with defaults appropriate for
+// the named manifest set.
+func NewManifestOptions(name string) *ManifestOptions {
+	return &ManifestOptions{
+		Name: name,
+		ManifestConfig: ManifestConfig{
+			EtcdAddress: EtcdAddress{
+				LocalHost: "127.0.0.1",
+			},
+		},
+	}
+}
+
+// AddFlags binds the manifest flags shared by all render subcommands.
+func (o *ManifestOptions) AddFlags(fs *pflag.FlagSet) {
+	o.Images.AddFlags(fs)
+}
@@ -0,0 +1,139 @@
+package render
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// defaultMetadataBaseURL is the well-known instance metadata endpoint shared
+// by AWS, Azure, GCP, and OpenStack.
+const defaultMetadataBaseURL = "http://169.254.169.254"
+
+// metadataClient is used for instance metadata lookups. It has a short
+// timeout so render fails fast rather than hanging when no metadata service
+// is reachable.
+var metadataClient = &http.Client{Timeout: 5 * time.Second}
+
+// listInterfaces and interfaceAddrs are indirected through package vars so
+// tests can substitute fake interfaces/addresses without touching the host
+// network stack.
+var (
+	listInterfaces = net.Interfaces
+	interfaceAddrs = func(iface net.Interface) ([]net.Addr, error) { return iface.Addrs() }
+)
+
+// resolveBootstrapIP discovers the bootstrap host's address for platform,
+// returning only an address of the family matching singleStackIPv6. It is used
+// to seed the initial etcd cluster when no --bootstrap-ip was supplied.
+// machineNetworkCIDR, when non-empty, restricts interface-based discovery
+// (BareMetal, None, vSphere) to addresses within that CIDR.
+func resolveBootstrapIP(platform configv1.PlatformType, metadataBaseURL string, singleStackIPv6 bool, machineNetworkCIDR string) (string, error) {
+	switch platform {
+	case configv1.AWSPlatformType, configv1.AzurePlatformType, configv1.GCPPlatformType, configv1.OpenStackPlatformType:
+		return bootstrapIPFromMetadata(metadataBaseURL, singleStackIPv6)
+	case configv1.VSpherePlatformType:
+		// vSphere VMs conventionally carry a single, primary NIC.
+		return bootstrapIPFromInterfaces(singleStackIPv6, true, machineNetworkCIDR)
+	case configv1.BareMetalPlatformType, configv1.NonePlatformType, "":
+		return bootstrapIPFromInterfaces(singleStackIPv6, false, machineNetworkCIDR)
+	default:
+		return "", fmt.Errorf("bootstrap IP discovery is not supported on platform %q", platform)
+	}
+}
+
+// bootstrapIPFromMetadata queries the cloud instance metadata service for
+// the bootstrap host's local IP address via the EC2-style
+// /latest/meta-data/local-ipv4 path, or local-ipv6 when singleStackIPv6 is
+// set. Only that one path is queried; there is no fallback to the other
+// family.
+func bootstrapIPFromMetadata(baseURL string, singleStackIPv6 bool) (string, error) {
+	path := "/latest/meta-data/local-ipv4"
+	if singleStackIPv6 {
+		path = "/latest/meta-data/local-ipv6"
+	}
+
+	resp, err := metadataClient.Get(baseURL + path)
+	if err != nil {
+		return "", fmt.Errorf("failed to query instance metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("instance metadata returned status %d for %s", resp.StatusCode, path)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+// bootstrapIPFromInterfaces returns the first non-loopback, up interface
+// address of the family matching singleStackIPv6. primaryOnly restricts the
+// search to the first such interface, matching platforms whose hosts carry a
+// single usable NIC; it has no effect when machineNetworkCIDR is non-empty,
+// and every interface is scanned. When machineNetworkCIDR is non-empty, only addresses
+// contained in it are considered, so a host with more than one routable
+// interface (e.g. a separate management NIC) doesn't silently bind the
+// wrong one.
+func bootstrapIPFromInterfaces(singleStackIPv6, primaryOnly bool, machineNetworkCIDR string) (string, error) {
+	var machineNet *net.IPNet
+	if machineNetworkCIDR != "" {
+		_, parsed, err := net.ParseCIDR(machineNetworkCIDR)
+		if err != nil {
+			return "", fmt.Errorf("invalid machine network CIDR %q: %w", machineNetworkCIDR, err)
+		}
+		machineNet = parsed
+	}
+
+	ifaces, err := listInterfaces()
+	if err != nil {
+		return "", fmt.Errorf("failed to enumerate network interfaces: %w", err)
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+
+		addrs, err := interfaceAddrs(iface)
+		if err != nil {
+			return "", err
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+
+			if (ipNet.IP.To4() != nil) == singleStackIPv6 {
+				continue
+			}
+
+			if machineNet != nil && !machineNet.Contains(ipNet.IP) {
+				continue
+			}
+
+			return ipNet.IP.String(), nil
+		}
+
+		// primaryOnly assumes the first non-loopback, up interface is the
+		// one usable NIC; that assumption doesn't hold once a machine
+		// network CIDR narrows the search, so keep scanning interfaces in
+		// that case instead of giving up after the first one.
+		if primaryOnly && machineNet == nil {
+			break
+		}
+	}
+
+	return "", fmt.Errorf("no usable network interface address found")
+}
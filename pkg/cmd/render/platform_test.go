@@ -0,0 +1,184 @@
+package render
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func fakeMetadataServer(t *testing.T, ipv4, ipv6 string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/meta-data/local-ipv4":
+			fmt.Fprint(w, ipv4)
+		case "/latest/meta-data/local-ipv6":
+			fmt.Fprint(w, ipv6)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestResolveBootstrapIPMetadataPlatforms(t *testing.T) {
+	server := fakeMetadataServer(t, "10.0.0.7", "2001:db8::7")
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		platform configv1.PlatformType
+	}{
+		{name: "AWS", platform: configv1.AWSPlatformType},
+		{name: "Azure", platform: configv1.AzurePlatformType},
+		{name: "GCP", platform: configv1.GCPPlatformType},
+		{name: "OpenStack", platform: configv1.OpenStackPlatformType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveBootstrapIP(tt.platform, server.URL, false, "")
+			if err != nil {
+				t.Fatalf("resolveBootstrapIP() error = %v", err)
+			}
+			if got != "10.0.0.7" {
+				t.Errorf("resolveBootstrapIP() = %q, want %q", got, "10.0.0.7")
+			}
+		})
+	}
+}
+
+func TestResolveBootstrapIPMetadataDualStack(t *testing.T) {
+	server := fakeMetadataServer(t, "10.0.0.7", "2001:db8::7")
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		singleStackIPv6 bool
+		want            string
+	}{
+		{name: "picks IPv4 when not single-stack IPv6", singleStackIPv6: false, want: "10.0.0.7"},
+		{name: "picks IPv6 when single-stack IPv6", singleStackIPv6: true, want: "2001:db8::7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveBootstrapIP(configv1.AWSPlatformType, server.URL, tt.singleStackIPv6, "")
+			if err != nil {
+				t.Fatalf("resolveBootstrapIP() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveBootstrapIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBootstrapIPUnsupportedPlatform(t *testing.T) {
+	if _, err := resolveBootstrapIP(configv1.PlatformType("Nutanix"), defaultMetadataBaseURL, false, ""); err == nil {
+		t.Error("resolveBootstrapIP() expected error for unsupported platform, got nil")
+	}
+}
+
+func TestBootstrapIPFromInterfacesFindsLocalAddress(t *testing.T) {
+	// The test host always has at least one non-loopback, up IPv4
+	// interface, so this should resolve without error.
+	if _, err := bootstrapIPFromInterfaces(false, false, ""); err != nil {
+		t.Errorf("bootstrapIPFromInterfaces() error = %v", err)
+	}
+}
+
+// fakeInterfaces stubs listInterfaces/interfaceAddrs for the duration of the
+// test, simulating a host with the given named interfaces and addresses.
+func fakeInterfaces(t *testing.T, ifaceAddrs map[string][]string) []net.Interface {
+	t.Helper()
+
+	names := make([]string, 0, len(ifaceAddrs))
+	for name := range ifaceAddrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ifaces := make([]net.Interface, 0, len(names))
+	for i, name := range names {
+		ifaces = append(ifaces, net.Interface{Index: i + 1, Name: name, Flags: net.FlagUp})
+	}
+
+	origListInterfaces, origInterfaceAddrs := listInterfaces, interfaceAddrs
+	listInterfaces = func() ([]net.Interface, error) { return ifaces, nil }
+	interfaceAddrs = func(iface net.Interface) ([]net.Addr, error) {
+		addrs := make([]net.Addr, 0, len(ifaceAddrs[iface.Name]))
+		for _, cidr := range ifaceAddrs[iface.Name] {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				t.Fatalf("invalid fake address %q: %v", cidr, err)
+			}
+			ip := net.ParseIP(strings.SplitN(cidr, "/", 2)[0])
+			ifaceIPNet := &net.IPNet{IP: ip, Mask: ipNet.Mask}
+			addrs = append(addrs, ifaceIPNet)
+		}
+		return addrs, nil
+	}
+	t.Cleanup(func() {
+		listInterfaces, interfaceAddrs = origListInterfaces, origInterfaceAddrs
+	})
+
+	return ifaces
+}
+
+func TestBootstrapIPFromInterfacesMachineNetworkCIDR(t *testing.T) {
+	// eth0 is a management NIC outside the machine network; eth1 is the
+	// cluster NIC. Without a machine network CIDR, iteration order alone
+	// picks eth0's address, which would be wrong.
+	fakeInterfaces(t, map[string][]string{
+		"eth0": {"192.168.1.10/24"},
+		"eth1": {"10.0.0.5/24"},
+	})
+
+	got, err := bootstrapIPFromInterfaces(false, false, "10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("bootstrapIPFromInterfaces() error = %v", err)
+	}
+	if got != "10.0.0.5" {
+		t.Errorf("bootstrapIPFromInterfaces() = %q, want %q", got, "10.0.0.5")
+	}
+}
+
+func TestBootstrapIPFromInterfacesPrimaryOnlyMachineNetworkCIDR(t *testing.T) {
+	// vSphere (primaryOnly=true) with a management NIC listed before the
+	// cluster NIC: the CIDR filter must keep scanning past eth0 instead of
+	// giving up after the first interface.
+	fakeInterfaces(t, map[string][]string{
+		"eth0": {"192.168.1.10/24"},
+		"eth1": {"10.0.0.5/24"},
+	})
+
+	got, err := bootstrapIPFromInterfaces(false, true, "10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("bootstrapIPFromInterfaces() error = %v", err)
+	}
+	if got != "10.0.0.5" {
+		t.Errorf("bootstrapIPFromInterfaces() = %q, want %q", got, "10.0.0.5")
+	}
+}
+
+func TestBootstrapIPFromInterfacesNoMachineNetworkCIDRPicksFirst(t *testing.T) {
+	fakeInterfaces(t, map[string][]string{
+		"eth0": {"192.168.1.10/24"},
+		"eth1": {"10.0.0.5/24"},
+	})
+
+	got, err := bootstrapIPFromInterfaces(false, false, "")
+	if err != nil {
+		t.Fatalf("bootstrapIPFromInterfaces() error = %v", err)
+	}
+	if got != "192.168.1.10" {
+		t.Errorf("bootstrapIPFromInterfaces() = %q, want %q", got, "192.168.1.10")
+	}
+}
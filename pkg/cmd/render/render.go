@@ -0,0 +1,335 @@
+// Package render implements the "cluster-etcd-operator render" command used
+// by the installer's bootkube bootstrap phase to produce the static etcd
+// manifests and operator config from the cluster's Network and
+// Infrastructure configs.
+package render
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-etcd-operator/pkg/cmd/render/options"
+)
+
+// TemplateData holds the data substituted into the bootkube etcd manifest
+// templates.
+type TemplateData struct {
+	options.ManifestConfig
+
+	// ClusterCIDR is the pod network CIDR(s) from the cluster's Network
+	// config, replaced by the Cluster API config's pod CIDRs when present.
+	ClusterCIDR []string
+	// ServiceCIDR is the service network CIDR(s) from the cluster's Network
+	// config, replaced by the Cluster API config's service CIDRs when present.
+	ServiceCIDR []string
+	// SingleStackIPv6 is true when the cluster's service network is IPv6-only.
+	SingleStackIPv6 bool
+	// BootstrapIP is the address of the bootstrap host, used to seed the
+	// initial etcd cluster before the operator takes over membership.
+	BootstrapIP string
+	// Platform is the infrastructure platform the cluster is running on,
+	// e.g. "AWS".
+	Platform string
+	// PlatformStatus is the full platform status from the cluster's
+	// Infrastructure config, so manifest templates can render
+	// platform-conditional flags beyond the Platform name.
+	PlatformStatus *configv1.PlatformStatus
+
+	// BindAddress is the wildcard address etcd's client, peer, and metrics
+	// listen URLs bind to: "0.0.0.0" for IPv4 and dual-stack clusters,
+	// "[::]" for IPv6 single-stack clusters. It is pre-bracketed so
+	// templates can embed it directly as the host part of a
+	// scheme://host:port listen URL.
+	BindAddress string
+
+	// Peers holds the etcd members' addresses, derived from the Cluster
+	// API Machine objects when --machine-config-file was supplied.
+	Peers []string
+	// InitialCluster is etcd's --initial-cluster string built from Peers.
+	InitialCluster string
+	// MemberName is this rendered pod's own --name within InitialCluster.
+	// render always runs on the bootstrap host, whose Machine is taken to be
+	// the first --machine-config-file (peerAddressesFromMachines keeps file
+	// order), so this is "etcd-member-0". Empty when InitialCluster is empty.
+	MemberName string
+	// AdvertisePeerURL is this host's --initial-advertise-peer-urls value,
+	// i.e. the address other peers dial to reach this member. Empty when
+	// InitialCluster is empty.
+	AdvertisePeerURL string
+	// AdvertiseClientURL is this host's --advertise-client-urls value.
+	// Empty when InitialCluster is empty.
+	AdvertiseClientURL string
+}
+
+// renderOpts holds the flags and parsed options for the render command.
+type renderOpts struct {
+	generic  options.GenericOptions
+	manifest options.ManifestOptions
+
+	errOut               io.Writer
+	clusterConfigFile    string
+	infraConfigFile      string
+	clusterAPIConfigFile string
+	machineConfigFiles   []string
+	bootstrapIP          string
+	machineNetworkCIDR   string
+}
+
+// NewCommand creates a "render" command that produces the static etcd
+// manifests and operator config consumed by the bootkube bootstrap process.
+func NewCommand(errOut io.Writer) *cobra.Command {
+	renderOpts := renderOpts{
+		generic:  *options.NewGenericOptions(),
+		manifest: *options.NewManifestOptions("etcd"),
+		errOut:   errOut,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Render etcd bootstrap manifests",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := renderOpts.Validate(); err != nil {
+				panic(err)
+			}
+			if err := renderOpts.Run(); err != nil {
+				panic(err)
+			}
+		},
+	}
+
+	renderOpts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// AddFlags binds the render command's flags.
+func (r *renderOpts) AddFlags(fs *pflag.FlagSet) {
+	r.generic.AddFlags(fs)
+	r.manifest.AddFlags(fs)
+
+	fs.StringVar(&r.clusterConfigFile, "cluster-config-file", r.clusterConfigFile, "File containing the cluster's Network config.")
+	fs.StringVar(&r.infraConfigFile, "cluster-infrastructure-config-file", r.infraConfigFile, "File containing the cluster's Infrastructure config.")
+	fs.StringVar(&r.clusterAPIConfigFile, "cluster-api-config", r.clusterAPIConfigFile, "File containing a Cluster API Cluster object; its pod/service CIDRs are preferred over --cluster-config-file when both are present.")
+	fs.StringArrayVar(&r.machineConfigFiles, "machine-config-file", r.machineConfigFiles, "File containing a Cluster API Machine object; repeat once per etcd member to derive peer addresses without a separate --bootstrap-ip.")
+	fs.StringVar(&r.bootstrapIP, "bootstrap-ip", r.bootstrapIP, "Address of the bootstrap host, used to seed the initial etcd cluster.")
+	fs.StringVar(&r.machineNetworkCIDR, "machine-network-cidr", r.machineNetworkCIDR, "CIDR of the machine network. When set, interface-based bootstrap IP discovery (BareMetal, None, vSphere) only considers addresses within it.")
+}
+
+// Validate checks that the required render flags were supplied.
+func (r *renderOpts) Validate() error {
+	if len(r.clusterConfigFile) == 0 {
+		return fmt.Errorf("missing required flag: --cluster-config-file")
+	}
+	if len(r.infraConfigFile) == 0 {
+		return fmt.Errorf("missing required flag: --cluster-infrastructure-config-file")
+	}
+	return nil
+}
+
+// Run reads the installer-provided cluster configuration, renders the
+// bootkube etcd manifest templates, and writes the resulting manifests and
+// operator config to the configured output directories.
+func (r *renderOpts) Run() error {
+	templateData, err := newTemplateData(r)
+	if err != nil {
+		return err
+	}
+
+	if err := renderManifests(r.generic.TemplatesDir, r.generic.AssetOutputDir, templateData); err != nil {
+		return err
+	}
+
+	if err := writeTFVars(r.generic.AssetOutputDir, templateData); err != nil {
+		return err
+	}
+
+	return writeConfigFile(r.generic.ConfigOutputFile, templateData)
+}
+
+// newTemplateData builds the TemplateData for a render run from the
+// cluster's Network and Infrastructure configs.
+func newTemplateData(opt *renderOpts) (*TemplateData, error) {
+	templateData := &TemplateData{
+		ManifestConfig: opt.manifest.ManifestConfig,
+	}
+
+	if err := templateData.setNetwork(opt.clusterConfigFile); err != nil {
+		return nil, err
+	}
+
+	if err := templateData.setPlatform(opt.infraConfigFile); err != nil {
+		return nil, err
+	}
+
+	if err := templateData.applyClusterAPIConfig(opt.clusterAPIConfigFile, opt.errOut); err != nil {
+		return nil, err
+	}
+
+	if len(opt.machineConfigFiles) > 0 {
+		peers, err := peerAddressesFromMachines(opt.machineConfigFiles)
+		if err != nil {
+			return nil, err
+		}
+		templateData.Peers = peers
+		templateData.InitialCluster = initialClusterFromPeers(peers)
+		if len(peers) > 0 {
+			// The first Machine's address seeds the bootstrap cluster, so a
+			// separate --bootstrap-ip is no longer required.
+			templateData.BootstrapIP = peers[0]
+		}
+	}
+
+	switch {
+	case opt.bootstrapIP != "":
+		templateData.BootstrapIP = opt.bootstrapIP
+	case templateData.BootstrapIP == "":
+		ip, err := resolveBootstrapIP(configv1.PlatformType(templateData.Platform), defaultMetadataBaseURL, templateData.SingleStackIPv6, opt.machineNetworkCIDR)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover bootstrap IP: %w", err)
+		}
+		templateData.BootstrapIP = ip
+	}
+
+	if templateData.InitialCluster != "" {
+		// This rendered pod is always etcd-member-0: see the MemberName
+		// doc comment for why.
+		templateData.MemberName = "etcd-member-0"
+		templateData.AdvertisePeerURL = fmt.Sprintf("https://%s", net.JoinHostPort(templateData.BootstrapIP, "2380"))
+		templateData.AdvertiseClientURL = fmt.Sprintf("https://%s", net.JoinHostPort(templateData.BootstrapIP, "2379"))
+	}
+
+	return templateData, nil
+}
+
+// setNetwork populates ClusterCIDR, ServiceCIDR, SingleStackIPv6,
+// BindAddress, and EtcdAddress.LocalHost from the cluster's Network config.
+// No separate bind network (tcp4/tcp6) value is derived.
+func (t *TemplateData) setNetwork(file string) error {
+	network := &configv1.Network{}
+	if err := readYAMLFile(file, network); err != nil {
+		return fmt.Errorf("failed to read cluster network config %q: %w", file, err)
+	}
+
+	t.ClusterCIDR = make([]string, 0, len(network.Spec.ClusterNetwork))
+	for _, entry := range network.Spec.ClusterNetwork {
+		t.ClusterCIDR = append(t.ClusterCIDR, entry.CIDR)
+	}
+	t.ServiceCIDR = append([]string{}, network.Spec.ServiceNetwork...)
+
+	t.setStackAddressing()
+
+	return nil
+}
+
+// setStackAddressing derives SingleStackIPv6, EtcdAddress.LocalHost, and
+// BindAddress from ServiceCIDR. Single-stack IPv6 means every service CIDR
+// is IPv6; IPv4-only and dual-stack (in either CIDR order) both bind the
+// IPv4 wildcard, since etcd's IPv4 listeners accept IPv6 traffic by default.
+func (t *TemplateData) setStackAddressing() {
+	t.SingleStackIPv6 = isSingleStackIPv6(t.ServiceCIDR)
+	if t.SingleStackIPv6 {
+		t.EtcdAddress.LocalHost = "[::1]"
+		t.BindAddress = "[::]"
+	} else {
+		t.EtcdAddress.LocalHost = "127.0.0.1"
+		t.BindAddress = "0.0.0.0"
+	}
+}
+
+// isSingleStackIPv6 reports whether serviceCIDRs describes an IPv6-only
+// service network.
+func isSingleStackIPv6(serviceCIDRs []string) bool {
+	if len(serviceCIDRs) == 0 {
+		return false
+	}
+	for _, cidr := range serviceCIDRs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil || ip.To4() != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// setPlatform populates Platform from the cluster's Infrastructure config.
+func (t *TemplateData) setPlatform(file string) error {
+	infra := &configv1.Infrastructure{}
+	if err := readYAMLFile(file, infra); err != nil {
+		return fmt.Errorf("failed to read cluster infrastructure config %q: %w", file, err)
+	}
+
+	if infra.Status.PlatformStatus != nil && infra.Status.PlatformStatus.Type != "" {
+		t.Platform = string(infra.Status.PlatformStatus.Type)
+	} else {
+		t.Platform = string(infra.Status.Platform)
+	}
+	t.PlatformStatus = infra.Status.PlatformStatus
+
+	return nil
+}
+
+// readYAMLFile reads file and unmarshals its YAML contents into obj.
+func readYAMLFile(file string, obj interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, obj)
+}
+
+// renderManifests executes every *.yaml template in templatesDir against
+// data and writes the result into outputDir/manifests.
+func renderManifests(templatesDir, outputDir string, data *TemplateData) error {
+	manifestsDir := filepath.Join(outputDir, "manifests")
+	if err := os.MkdirAll(manifestsDir, 0755); err != nil {
+		return err
+	}
+
+	return filepath.Walk(templatesDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".yaml" {
+			return nil
+		}
+
+		tmpl, err := template.New(info.Name()).ParseFiles(path)
+		if err != nil {
+			return fmt.Errorf("failed to parse template %q: %w", path, err)
+		}
+
+		out, err := os.Create(filepath.Join(manifestsDir, info.Name()))
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		return tmpl.ExecuteTemplate(out, info.Name(), data)
+	})
+}
+
+// writeConfigFile writes the network config consumed by the operator's
+// config observer once it takes over from the bootstrap manifests.
+func writeConfigFile(path string, data *TemplateData) error {
+	config := map[string]interface{}{
+		"clusterCIDR": data.ClusterCIDR,
+		"serviceCIDR": data.ServiceCIDR,
+	}
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, out, 0644)
+}
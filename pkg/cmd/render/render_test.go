@@ -2,10 +2,12 @@ package render
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	configv1 "github.com/openshift/api/config/v1"
@@ -141,6 +143,7 @@ func TestRenderIpv4(t *testing.T) {
 		clusterNetworkConfig: networkConfigIpv4,
 		infraConfig:          infraConfig,
 		want:                 want,
+		bootstrapIP:          "10.0.0.5",
 	}
 
 	testRender(config)
@@ -187,6 +190,7 @@ func testRender(tc *testConfig) {
 		errOut:            errOut,
 		clusterConfigFile: clusterConfigFile.Name(),
 		infraConfigFile:   infraConfigFile.Name(),
+		bootstrapIP:       tc.bootstrapIP,
 	}
 
 	if err := render.Run(); err != nil {
@@ -211,6 +215,7 @@ func TestTemplateDataIpv4(t *testing.T) {
 		clusterNetworkConfig: networkConfigIpv4,
 		infraConfig:          infraConfig,
 		want:                 want,
+		bootstrapIP:          "10.0.0.5",
 	}
 	testTemplateData(config)
 }
@@ -232,6 +237,7 @@ func TestTemplateDataMixed(t *testing.T) {
 		clusterNetworkConfig: networkConfigMixedSwap,
 		infraConfig:          infraConfig,
 		want:                 want,
+		bootstrapIP:          "10.0.0.6",
 	}
 	testTemplateData(config)
 }
@@ -329,6 +335,260 @@ func testTemplateData(tc *testConfig) {
 	}
 }
 
+func TestTemplateDataBindAddress(t *testing.T) {
+	tests := []struct {
+		name                 string
+		clusterNetworkConfig string
+		wantBindAddress      string
+	}{
+		{
+			name:                 "ipv4",
+			clusterNetworkConfig: networkConfigIpv4,
+			wantBindAddress:      "0.0.0.0",
+		},
+		{
+			name:                 "mixed",
+			clusterNetworkConfig: networkConfigMixed,
+			wantBindAddress:      "0.0.0.0",
+		},
+		{
+			name:                 "mixed swap",
+			clusterNetworkConfig: networkConfigMixedSwap,
+			wantBindAddress:      "0.0.0.0",
+		},
+		{
+			name:                 "single-stack ipv6",
+			clusterNetworkConfig: networkConfigIPv6SingleStack,
+			wantBindAddress:      "[::]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &testConfig{
+				t:                    t,
+				clusterNetworkConfig: tt.clusterNetworkConfig,
+				infraConfig:          infraConfig,
+			}
+
+			dir, err := ioutil.TempDir("/tmp", "assets-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			clusterConfigFile, err := ioutil.TempFile(dir, "cluster-network-02-config.*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer clusterConfigFile.Close()
+
+			infraConfigFile, err := ioutil.TempFile(dir, "cluster-infrastructure-02-config.*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer infraConfigFile.Close()
+
+			if err := writeFile(config.clusterNetworkConfig, clusterConfigFile); err != nil {
+				t.Fatal(err)
+			}
+			if err := writeFile(config.infraConfig, infraConfigFile); err != nil {
+				t.Fatal(err)
+			}
+
+			render := &renderOpts{
+				generic:           options.GenericOptions{},
+				manifest:          *options.NewManifestOptions("etcd"),
+				clusterConfigFile: clusterConfigFile.Name(),
+				infraConfigFile:   infraConfigFile.Name(),
+				bootstrapIP:       "10.0.0.5",
+			}
+
+			got, err := newTemplateData(render)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.BindAddress != tt.wantBindAddress {
+				t.Errorf("BindAddress want: %q got: %q", tt.wantBindAddress, got.BindAddress)
+			}
+		})
+	}
+}
+
+// TestRenderEtcdMemberPodListenURLs executes the real etcd-member-pod.yaml
+// template and asserts on the rendered --listen-*-urls flags, so a
+// regression to an invalid scheme (e.g. "tcp4"/"tcp6" instead of "https") or
+// an unbracketed IPv6 host doesn't ship unnoticed.
+func TestRenderEtcdMemberPodListenURLs(t *testing.T) {
+	tests := []struct {
+		name                 string
+		clusterNetworkConfig string
+		wantHost             string
+	}{
+		{
+			name:                 "ipv4",
+			clusterNetworkConfig: networkConfigIpv4,
+			wantHost:             "0.0.0.0",
+		},
+		{
+			name:                 "single-stack ipv6",
+			clusterNetworkConfig: networkConfigIPv6SingleStack,
+			wantHost:             "[::]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("/tmp", "assets-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			clusterConfigFile, err := ioutil.TempFile(dir, "cluster-network-02-config.*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer clusterConfigFile.Close()
+
+			infraConfigFile, err := ioutil.TempFile(dir, "cluster-infrastructure-02-config.*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer infraConfigFile.Close()
+
+			if err := writeFile(tt.clusterNetworkConfig, clusterConfigFile); err != nil {
+				t.Fatal(err)
+			}
+			if err := writeFile(infraConfig, infraConfigFile); err != nil {
+				t.Fatal(err)
+			}
+
+			render := renderOpts{
+				generic: options.GenericOptions{
+					AssetInputDir:    dir,
+					AssetOutputDir:   dir,
+					TemplatesDir:     filepath.Join("../../..", "bindata", "bootkube"),
+					ConfigOutputFile: filepath.Join(dir, "config"),
+				},
+				manifest:          *options.NewManifestOptions("etcd"),
+				clusterConfigFile: clusterConfigFile.Name(),
+				infraConfigFile:   infraConfigFile.Name(),
+				bootstrapIP:       "10.0.0.5",
+			}
+
+			if err := render.Run(); err != nil {
+				t.Fatalf("render.Run() error = %v", err)
+			}
+
+			out, err := ioutil.ReadFile(filepath.Join(dir, "manifests", "etcd-member-pod.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range []string{
+				fmt.Sprintf("--listen-client-urls=https://%s:2379", tt.wantHost),
+				fmt.Sprintf("--listen-peer-urls=https://%s:2380", tt.wantHost),
+				fmt.Sprintf("--listen-metrics-urls=https://%s:9978", tt.wantHost),
+			} {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("rendered manifest missing %q, got:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+// TestRenderWithMachineConfigFilesSetsInitialCluster exercises
+// --machine-config-file end to end through render.Run(), proving that the
+// derived Peers/InitialCluster actually reach the rendered manifest instead
+// of only being computed and discarded.
+func TestRenderWithMachineConfigFilesSetsInitialCluster(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "assets-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clusterConfigFile, err := ioutil.TempFile(dir, "cluster-network-02-config.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clusterConfigFile.Close()
+
+	infraConfigFile, err := ioutil.TempFile(dir, "cluster-infrastructure-02-config.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer infraConfigFile.Close()
+
+	if err := writeFile(networkConfigIpv4, clusterConfigFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(infraConfig, infraConfigFile); err != nil {
+		t.Fatal(err)
+	}
+
+	machine0 := writeTempYAML(t, `
+apiVersion: machine.openshift.io/v1beta1
+kind: Machine
+metadata:
+  name: master-0
+status:
+  addresses:
+  - type: InternalIP
+    address: 10.0.0.1
+`)
+	defer os.Remove(machine0)
+
+	machine1 := writeTempYAML(t, `
+apiVersion: machine.openshift.io/v1beta1
+kind: Machine
+metadata:
+  name: master-1
+status:
+  addresses:
+  - type: InternalIP
+    address: 10.0.0.2
+`)
+	defer os.Remove(machine1)
+
+	render := renderOpts{
+		generic: options.GenericOptions{
+			AssetInputDir:    dir,
+			AssetOutputDir:   dir,
+			TemplatesDir:     filepath.Join("../../..", "bindata", "bootkube"),
+			ConfigOutputFile: filepath.Join(dir, "config"),
+		},
+		manifest:           *options.NewManifestOptions("etcd"),
+		clusterConfigFile:  clusterConfigFile.Name(),
+		infraConfigFile:    infraConfigFile.Name(),
+		machineConfigFiles: []string{machine0, machine1},
+	}
+
+	if err := render.Run(); err != nil {
+		t.Fatalf("render.Run() error = %v", err)
+	}
+
+	out, err := ioutil.ReadFile(filepath.Join(dir, "manifests", "etcd-member-pod.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"--name=etcd-member-0",
+		"--initial-cluster=etcd-member-0=https://10.0.0.1:2380,etcd-member-1=https://10.0.0.2:2380",
+		"--initial-cluster-state=new",
+		"--initial-advertise-peer-urls=https://10.0.0.1:2380",
+		"--advertise-client-urls=https://10.0.0.1:2379",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("rendered manifest missing %q, got:\n%s", want, out)
+		}
+	}
+}
+
 func writeFile(input string, w io.Writer) error {
 	var buffer bytes.Buffer
 	buffer.WriteString(input)
@@ -381,6 +641,112 @@ status:
 			wantErr:      false,
 			wantPlatform: "",
 		},
+		{
+			name: "test infra config file with Azure",
+			infraSpec: `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+metadata:
+  name: cluster
+spec:
+  cloudConfig:
+    name: ""
+status:
+  platform: Azure
+  platformStatus:
+    azure:
+      cloudName: AzurePublicCloud
+    type: Azure
+`,
+			wantErr:      false,
+			wantPlatform: configv1.AzurePlatformType,
+		},
+		{
+			name: "test infra config file with GCP",
+			infraSpec: `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+metadata:
+  name: cluster
+spec:
+  cloudConfig:
+    name: ""
+status:
+  platform: GCP
+  platformStatus:
+    gcp:
+      region: us-east1
+    type: GCP
+`,
+			wantErr:      false,
+			wantPlatform: configv1.GCPPlatformType,
+		},
+		{
+			name: "test infra config file with OpenStack",
+			infraSpec: `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+metadata:
+  name: cluster
+spec:
+  cloudConfig:
+    name: ""
+status:
+  platform: OpenStack
+  platformStatus:
+    type: OpenStack
+`,
+			wantErr:      false,
+			wantPlatform: configv1.OpenStackPlatformType,
+		},
+		{
+			name: "test infra config file with vSphere",
+			infraSpec: `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+metadata:
+  name: cluster
+spec:
+  cloudConfig:
+    name: ""
+status:
+  platform: VSphere
+  platformStatus:
+    type: VSphere
+`,
+			wantErr:      false,
+			wantPlatform: configv1.VSpherePlatformType,
+		},
+		{
+			name: "test infra config file with BareMetal",
+			infraSpec: `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+metadata:
+  name: cluster
+spec:
+  cloudConfig:
+    name: ""
+status:
+  platform: BareMetal
+  platformStatus:
+    type: BareMetal
+`,
+			wantErr:      false,
+			wantPlatform: configv1.BareMetalPlatformType,
+		},
+		{
+			name: "test infra config file with None",
+			infraSpec: `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+metadata:
+  name: cluster
+spec:
+  cloudConfig:
+    name: ""
+status:
+  platform: None
+  platformStatus:
+    type: None
+`,
+			wantErr:      false,
+			wantPlatform: configv1.NonePlatformType,
+		},
 	}
 	for _, tt := range tests {
 		t1.Run(tt.name, func(t1 *testing.T) {
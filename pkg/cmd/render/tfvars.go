@@ -0,0 +1,47 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// writeTFVars emits etcd.auto.tfvars.json, and a per-platform
+// etcd-<platform>.auto.tfvars.json when the platform is known, into
+// outputDir. This follows the openshift-install pattern of splitting
+// Terraform variables into per-platform *.auto.tfvars.json files, so the
+// installer's Terraform phase can consume etcd topology without re-parsing
+// the bootkube manifests.
+func writeTFVars(outputDir string, data *TemplateData) error {
+	vars := map[string]interface{}{
+		"etcd_bootstrap_ip":      data.BootstrapIP,
+		"etcd_service_cidr":      data.ServiceCIDR,
+		"etcd_cluster_cidr":      data.ClusterCIDR,
+		"etcd_single_stack_ipv6": data.SingleStackIPv6,
+		"etcd_localhost":         data.EtcdAddress.LocalHost,
+		"etcd_platform":          data.Platform,
+		"etcd_peers":             data.Peers,
+	}
+
+	if err := writeJSONFile(filepath.Join(outputDir, "etcd.auto.tfvars.json"), vars); err != nil {
+		return err
+	}
+
+	if data.Platform == "" {
+		return nil
+	}
+
+	platformFile := fmt.Sprintf("etcd-%s.auto.tfvars.json", strings.ToLower(data.Platform))
+	return writeJSONFile(filepath.Join(outputDir, platformFile), vars)
+}
+
+// writeJSONFile marshals v as indented JSON and writes it to path.
+func writeJSONFile(path string, v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0644)
+}
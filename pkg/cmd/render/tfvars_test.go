@@ -0,0 +1,181 @@
+package render
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/openshift/cluster-etcd-operator/pkg/cmd/render/options"
+)
+
+func TestRenderTFVarsIpv4(t *testing.T) {
+	testRenderTFVars(t, networkConfigIpv4, "10.0.0.5", map[string]interface{}{
+		"etcd_bootstrap_ip":      "10.0.0.5",
+		"etcd_single_stack_ipv6": false,
+		"etcd_localhost":         "127.0.0.1",
+	})
+}
+
+func TestRenderTFVarsMixed(t *testing.T) {
+	testRenderTFVars(t, networkConfigMixedSwap, "10.0.0.6", map[string]interface{}{
+		"etcd_bootstrap_ip":      "10.0.0.6",
+		"etcd_single_stack_ipv6": false,
+		"etcd_localhost":         "127.0.0.1",
+	})
+}
+
+func TestRenderTFVarsSingleStackIPv6(t *testing.T) {
+	testRenderTFVars(t, networkConfigIPv6SingleStack, "2001:0DB8:C21A", map[string]interface{}{
+		"etcd_bootstrap_ip":      "2001:0DB8:C21A",
+		"etcd_single_stack_ipv6": true,
+		"etcd_localhost":         "[::1]",
+	})
+}
+
+func TestRenderTFVarsPeers(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "assets-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clusterConfigFile, err := ioutil.TempFile(dir, "cluster-network-02-config.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clusterConfigFile.Close()
+
+	infraConfigFile, err := ioutil.TempFile(dir, "cluster-infrastructure-02-config.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer infraConfigFile.Close()
+
+	if err := writeFile(networkConfigIpv4, clusterConfigFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(infraConfig, infraConfigFile); err != nil {
+		t.Fatal(err)
+	}
+
+	machine0 := writeTempYAML(t, `
+apiVersion: machine.openshift.io/v1beta1
+kind: Machine
+metadata:
+  name: master-0
+status:
+  addresses:
+  - type: InternalIP
+    address: 10.0.0.1
+`)
+	defer os.Remove(machine0)
+
+	generic := options.GenericOptions{
+		AssetInputDir:    dir,
+		AssetOutputDir:   dir,
+		TemplatesDir:     filepath.Join("../../..", "bindata", "bootkube"),
+		ConfigOutputFile: filepath.Join(dir, "config"),
+	}
+
+	render := &renderOpts{
+		generic:            generic,
+		manifest:           *options.NewManifestOptions("etcd"),
+		clusterConfigFile:  clusterConfigFile.Name(),
+		infraConfigFile:    infraConfigFile.Name(),
+		machineConfigFiles: []string{machine0},
+	}
+
+	if err := render.Run(); err != nil {
+		t.Fatalf("failed render.Run(): %v", err)
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "etcd.auto.tfvars.json"))
+	if err != nil {
+		t.Fatalf("failed to read etcd.auto.tfvars.json: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("failed to unmarshal etcd.auto.tfvars.json: %v", err)
+	}
+
+	want := []interface{}{"10.0.0.1"}
+	if !reflect.DeepEqual(got["etcd_peers"], want) {
+		t.Errorf("etcd_peers want: %v got: %v", want, got["etcd_peers"])
+	}
+}
+
+// testRenderTFVars runs a full render and asserts that the resulting
+// etcd.auto.tfvars.json contains the given key/value pairs and that a
+// per-platform tfvars file was also written for the AWS infraConfig fixture.
+func testRenderTFVars(t *testing.T, clusterNetworkConfig, bootstrapIP string, want map[string]interface{}) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("/tmp", "assets-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clusterConfigFile, err := ioutil.TempFile(dir, "cluster-network-02-config.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clusterConfigFile.Close()
+
+	infraConfigFile, err := ioutil.TempFile(dir, "cluster-infrastructure-02-config.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer infraConfigFile.Close()
+
+	if err := writeFile(clusterNetworkConfig, clusterConfigFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(infraConfig, infraConfigFile); err != nil {
+		t.Fatal(err)
+	}
+
+	generic := options.GenericOptions{
+		AssetInputDir:    dir,
+		AssetOutputDir:   dir,
+		TemplatesDir:     filepath.Join("../../..", "bindata", "bootkube"),
+		ConfigOutputFile: filepath.Join(dir, "config"),
+	}
+
+	render := &renderOpts{
+		generic:           generic,
+		manifest:          *options.NewManifestOptions("etcd"),
+		clusterConfigFile: clusterConfigFile.Name(),
+		infraConfigFile:   infraConfigFile.Name(),
+		bootstrapIP:       bootstrapIP,
+	}
+
+	if err := render.Run(); err != nil {
+		t.Fatalf("failed render.Run(): %v", err)
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "etcd.auto.tfvars.json"))
+	if err != nil {
+		t.Fatalf("failed to read etcd.auto.tfvars.json: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("failed to unmarshal etcd.auto.tfvars.json: %v", err)
+	}
+
+	for key, wantValue := range want {
+		if !reflect.DeepEqual(got[key], wantValue) {
+			t.Errorf("%s want: %v got: %v", key, wantValue, got[key])
+		}
+	}
+
+	// infraConfig is the AWS fixture, so a per-platform file should also be written.
+	if _, err := os.Stat(filepath.Join(dir, "etcd-aws.auto.tfvars.json")); err != nil {
+		t.Errorf("expected per-platform tfvars file to exist: %v", err)
+	}
+}